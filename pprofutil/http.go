@@ -9,30 +9,102 @@ package pprofutil
 
 import (
 	"context"
+	"hash/fnv"
 	"net/http"
 	"runtime/pprof"
+	"strconv"
 )
 
+// HandlerOption configures the handler returned by NewLabelHandler.
+type HandlerOption func(*labelHandler)
+
+// WithMethodLabel additionally adds "http-method" profiler label
+// with the incoming request's method.
+func WithMethodLabel() HandlerOption {
+	return func(l *labelHandler) {
+		l.method = true
+	}
+}
+
+// WithPathSet limits "http-path" profiler label to the given paths.
+// Requests to any other path are labeled "other", which keeps the
+// number of distinct label values bounded.
+func WithPathSet(paths ...string) HandlerOption {
+	return func(l *labelHandler) {
+		l.paths = make(map[string]bool, len(paths))
+		for _, p := range paths {
+			l.paths[p] = true
+		}
+	}
+}
+
+// WithPathBuckets replaces "http-path" profiler label with "http-path-bucket",
+// which is the hash of the path reduced to one of n buckets, numbered from 0
+// to n-1. The same path is always labeled with the same bucket.
+// It is ignored if n is not positive.
+func WithPathBuckets(n int) HandlerOption {
+	return func(l *labelHandler) {
+		if n > 0 {
+			l.buckets = n
+		}
+	}
+}
+
 // LabelHandler adds "http-path" profiler label to the given handler.
-// If you want to start new goroutines from h, propagate the labels by
-// passing handler's incoming request's context.
+// h is called with a shallow copy of the incoming request whose context
+// carries the labels. If you want to start new goroutines from h, propagate
+// the labels by passing that request's context.
+//
+// Use NewLabelHandler to configure the labels with HandlerOptions.
 func LabelHandler(h http.Handler) http.Handler {
-	return &labelHandler{orig: h}
+	return NewLabelHandler(h)
 }
 
 // LabelHandlerFunc adds "http-path" profiler label to the given handler function.
 // If you want to start new goroutines from h, propagate the labels by passing r.Context().
+//
+// Use NewLabelHandler to configure the labels with HandlerOptions.
 func LabelHandlerFunc(fn func(w http.ResponseWriter, r *http.Request)) http.Handler {
-	return &labelHandler{orig: http.HandlerFunc(fn)}
+	return NewLabelHandler(http.HandlerFunc(fn))
+}
+
+// NewLabelHandler is like LabelHandler, but the profiler labels
+// added to h are configured by the given options.
+// Without options, it adds "http-path" label only; see the HandlerOption
+// constructors for the labels they add or replace.
+func NewLabelHandler(h http.Handler, opts ...HandlerOption) http.Handler {
+	l := &labelHandler{orig: h}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
 }
 
 type labelHandler struct {
-	orig http.Handler
+	orig    http.Handler
+	method  bool
+	paths   map[string]bool // nil if all paths are labeled as is
+	buckets int             // 0 if paths are not bucketed
 }
 
 func (l *labelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	labels := pprof.Labels("http-path", r.URL.Path)
-	pprof.Do(r.Context(), labels, func(ctx context.Context) {
-		l.orig.ServeHTTP(w, r)
+	path := r.URL.Path
+	if l.paths != nil && !l.paths[path] {
+		path = "other"
+	}
+	var args []string
+	if l.buckets > 0 {
+		h := fnv.New32a()
+		h.Write([]byte(path))
+		bucket := uint64(h.Sum32()) % uint64(l.buckets)
+		args = []string{"http-path-bucket", strconv.FormatUint(bucket, 10)}
+	} else {
+		args = []string{"http-path", path}
+	}
+	if l.method {
+		args = append(args, "http-method", r.Method)
+	}
+	pprof.Do(r.Context(), pprof.Labels(args...), func(ctx context.Context) {
+		l.orig.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -0,0 +1,144 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pprofutil
+
+import (
+	"context"
+	"hash/fnv"
+	"net/http"
+	"net/http/httptest"
+	"runtime/pprof"
+	"strconv"
+	"testing"
+)
+
+func labelMap(ctx context.Context) map[string]string {
+	labels := make(map[string]string)
+	pprof.ForLabels(ctx, func(k, v string) bool {
+		labels[k] = v
+		return true
+	})
+	return labels
+}
+
+// serveLabels serves a request to path through NewLabelHandler with opts
+// and returns the profiler labels visible in the request's context.
+func serveLabels(method, path string, opts ...HandlerOption) map[string]string {
+	var labels map[string]string
+	h := NewLabelHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		labels = labelMap(r.Context())
+	}), opts...)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, path, nil))
+	return labels
+}
+
+func TestLabelHandler(t *testing.T) {
+	var labels map[string]string
+	h := LabelHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		labels = labelMap(r.Context())
+	})
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/foo", nil))
+	if got, want := labels["http-path"], "/foo"; got != want {
+		t.Errorf("http-path = %q; want %q", got, want)
+	}
+	if v, ok := labels["http-method"]; ok {
+		t.Errorf("http-method = %q; want no label without WithMethodLabel", v)
+	}
+}
+
+func TestLabelHandlerMethod(t *testing.T) {
+	labels := serveLabels("POST", "/foo", WithMethodLabel())
+	if got, want := labels["http-method"], "POST"; got != want {
+		t.Errorf("http-method = %q; want %q", got, want)
+	}
+	if got, want := labels["http-path"], "/foo"; got != want {
+		t.Errorf("http-path = %q; want %q", got, want)
+	}
+}
+
+func TestLabelHandlerPathSet(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/foo", want: "/foo"},
+		{path: "/bar", want: "/bar"},
+		{path: "/baz", want: "other"},
+		{path: "/foo/1", want: "other"},
+	}
+	for _, tt := range tests {
+		labels := serveLabels("GET", tt.path, WithPathSet("/foo", "/bar"))
+		if got := labels["http-path"]; got != tt.want {
+			t.Errorf("%s: http-path = %q; want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func wantBucket(path string, n int) string {
+	h := fnv.New32a()
+	h.Write([]byte(path))
+	return strconv.FormatUint(uint64(h.Sum32()%uint32(n)), 10)
+}
+
+func TestLabelHandlerPathBuckets(t *testing.T) {
+	const n = 8
+	paths := []string{"/", "/foo", "/bar", "/foo/1", "/foo/2", "/users/42", "/a/b/c/d"}
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		for i := 0; i < 2; i++ {
+			labels := serveLabels("GET", path, WithPathBuckets(n))
+			if v, ok := labels["http-path"]; ok {
+				t.Errorf("%s: http-path = %q; want no label when bucketing", path, v)
+			}
+			got := labels["http-path-bucket"]
+			if want := wantBucket(path, n); got != want {
+				t.Errorf("%s: http-path-bucket = %q; want %q", path, got, want)
+			}
+			if b, err := strconv.Atoi(got); err != nil || b < 0 || b >= n {
+				t.Errorf("%s: http-path-bucket = %q; want in [0, %d)", path, got, n)
+			}
+			seen[got] = true
+		}
+	}
+	if len(seen) < 2 {
+		t.Errorf("%d paths fell into %d bucket(s); want more than one", len(paths), len(seen))
+	}
+}
+
+func TestLabelHandlerPathSetBuckets(t *testing.T) {
+	const n = 8
+	opts := []HandlerOption{WithPathSet("/foo"), WithPathBuckets(n)}
+	if got, want := serveLabels("GET", "/foo", opts...)["http-path-bucket"], wantBucket("/foo", n); got != want {
+		t.Errorf("/foo: http-path-bucket = %q; want %q", got, want)
+	}
+	for _, path := range []string{"/bar", "/baz/1"} {
+		if got, want := serveLabels("GET", path, opts...)["http-path-bucket"], wantBucket("other", n); got != want {
+			t.Errorf("%s: http-path-bucket = %q; want %q (bucket of \"other\")", path, got, want)
+		}
+	}
+}
+
+func benchmarkLabelHandler(b *testing.B, opts ...HandlerOption) {
+	h := NewLabelHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), opts...)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/foo", nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.ServeHTTP(w, r)
+	}
+}
+
+func BenchmarkLabelHandler(b *testing.B) {
+	b.Run("path", func(b *testing.B) {
+		benchmarkLabelHandler(b)
+	})
+	b.Run("path+method", func(b *testing.B) {
+		benchmarkLabelHandler(b, WithMethodLabel())
+	})
+	b.Run("bucket", func(b *testing.B) {
+		benchmarkLabelHandler(b, WithPathBuckets(16))
+	})
+}
@@ -0,0 +1,24 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pprofutil
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// WithLabels returns a new context that carries the labels already in ctx
+// merged with the given labels. If a key is present in both, the value
+// from labels wins, so the last wrapper to call WithLabels takes precedence.
+//
+// Like pprof.WithLabels, it does not apply the labels to the current goroutine;
+// use pprof.SetGoroutineLabels or pprof.Do with the returned context.
+func WithLabels(ctx context.Context, labels map[string]string) context.Context {
+	args := make([]string, 0, 2*len(labels))
+	for k, v := range labels {
+		args = append(args, k, v)
+	}
+	return pprof.WithLabels(ctx, pprof.Labels(args...))
+}
@@ -0,0 +1,39 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pprofutil
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"runtime/pprof"
+	"testing"
+)
+
+func TestWithLabels(t *testing.T) {
+	ctx := WithLabels(context.Background(), map[string]string{"a": "1", "b": "1"})
+	ctx = WithLabels(ctx, map[string]string{"b": "2", "c": "2"})
+	want := map[string]string{"a": "1", "b": "2", "c": "2"}
+	if got := labelMap(ctx); !reflect.DeepEqual(got, want) {
+		t.Errorf("labels = %v; want %v", got, want)
+	}
+}
+
+func TestWithLabelsLabelHandler(t *testing.T) {
+	var got map[string]string
+	h := NewLabelHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := WithLabels(r.Context(), map[string]string{"extra": "1", "http-method": "override"})
+		pprof.Do(ctx, pprof.Labels(), func(ctx context.Context) {
+			got = labelMap(ctx)
+		})
+	}), WithMethodLabel())
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/foo", nil))
+
+	want := map[string]string{"http-path": "/foo", "http-method": "override", "extra": "1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("labels = %v; want %v", got, want)
+	}
+}